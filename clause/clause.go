@@ -0,0 +1,53 @@
+// Package clause provides typed SQL condition expressions
+package clause
+
+import (
+	"strings"
+
+	"github.com/go-labx/orm/dialect"
+)
+
+// Expression is a SQL condition that renders itself into a Builder
+type Expression interface {
+	Build(b *Builder)
+}
+
+// Builder accumulates the SQL text and bound variables of an expression.
+type Builder struct {
+	dialect dialect.Dialect
+	sql     strings.Builder
+	Vars    []interface{} // Vars are the bound variables in placeholder order.
+}
+
+// NewBuilder creates a Builder that quotes identifiers with the given dialect.
+func NewBuilder(d dialect.Dialect) *Builder {
+	return &Builder{dialect: d}
+}
+
+// WriteString appends raw SQL text.
+func (b *Builder) WriteString(s string) {
+	b.sql.WriteString(s)
+}
+
+// WriteQuoted appends a column name quoted by the dialect.
+func (b *Builder) WriteQuoted(name string) {
+	b.sql.WriteString(b.dialect.Quote(name))
+}
+
+// AddVar appends a placeholder and binds v to it.
+func (b *Builder) AddVar(v interface{}) {
+	b.sql.WriteString("?")
+	b.Vars = append(b.Vars, v)
+}
+
+// SQL returns the SQL text built so far.
+func (b *Builder) SQL() string {
+	return b.sql.String()
+}
+
+// Build renders expr with the given dialect and returns the SQL and its bound variables.
+func Build(d dialect.Dialect, expr Expression) (string, []interface{}) {
+	b := NewBuilder(d)
+	expr.Build(b)
+	return b.SQL(), b.Vars
+}
@@ -0,0 +1,69 @@
+package clause
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/go-labx/orm/dialect"
+)
+
+type User struct {
+	Name      string
+	Age       int
+	CreatedAt int64          `db:"created_at"`
+	Nickname  sql.NullString `db:"nickname"`
+	Email     *string        `db:"email"`
+}
+
+var TestDial, _ = dialect.GetDialect("mysql")
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     Expression
+		expected string
+		vars     []interface{}
+	}{
+		{"Eq", Eq{Column: "name", Value: "tom"}, "`name` = ?", []interface{}{"tom"}},
+		{"Eq nil", Eq{Column: "deleted_at"}, "`deleted_at` IS NULL", nil},
+		{"Neq nil", Neq{Column: "deleted_at"}, "NOT (`deleted_at` IS NULL)", nil},
+		{"Qualified column", Gt{Column: "user.age", Value: 18}, "`user`.`age` > ?", []interface{}{18}},
+		{"In", In{Column: "id", Values: []interface{}{1, 2}}, "`id` IN (?, ?)", []interface{}{1, 2}},
+		{"Empty in", In{Column: "id"}, "1 = 0", nil},
+		{"Between", Between{Column: "age", From: 1, To: 9}, "`age` BETWEEN ? AND ?", []interface{}{1, 9}},
+		{"Like", Like{Column: "name", Pattern: "t%"}, "`name` LIKE ?", []interface{}{"t%"}},
+		{
+			"Nested groups",
+			And(Eq{Column: "a", Value: 1}, Or(Lt{Column: "b", Value: 2}, Gte{Column: "c", Value: 3}), And()),
+			"`a` = ? AND (`b` < ? OR `c` >= ?)",
+			[]interface{}{1, 2, 3},
+		},
+		{
+			"Map",
+			Map(map[string]interface{}{"name": "tom", "id": []int{1, 2}}),
+			"`id` IN (?, ?) AND `name` = ?",
+			[]interface{}{1, 2, "tom"},
+		},
+		{"Struct", Struct(&User{Name: "tom"}), "`Name` = ?", []interface{}{"tom"}},
+		{
+			"Struct tags",
+			Struct(User{CreatedAt: 5, Nickname: sql.NullString{String: "t", Valid: true}}),
+			"`created_at` = ? AND `nickname` = ?",
+			[]interface{}{int64(5), sql.NullString{String: "t", Valid: true}},
+		},
+		{"Empty not", And(Eq{Column: "a", Value: 1}, Not(And())), "`a` = ?", []interface{}{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars := Build(TestDial, tt.expr)
+			if sql != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, sql)
+			}
+			if !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("expected vars %v, got %v", tt.vars, vars)
+			}
+		})
+	}
+}
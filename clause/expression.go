@@ -0,0 +1,282 @@
+package clause
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sort"
+
+	"github.com/go-labx/orm/schema"
+)
+
+// Eq renders "column = value", or "column IS NULL" when Value is nil.
+type Eq struct {
+	Column string
+	Value  interface{}
+}
+
+func (e Eq) Build(b *Builder) {
+	if e.Value == nil {
+		IsNull{Column: e.Column}.Build(b)
+		return
+	}
+	buildComparison(b, e.Column, " = ", e.Value)
+}
+
+// Neq renders "column <> value", or "column IS NOT NULL" when Value is nil.
+type Neq struct {
+	Column string
+	Value  interface{}
+}
+
+func (n Neq) Build(b *Builder) {
+	if n.Value == nil {
+		Not(IsNull{Column: n.Column}).Build(b)
+		return
+	}
+	buildComparison(b, n.Column, " <> ", n.Value)
+}
+
+// Gt renders "column > value".
+type Gt struct {
+	Column string
+	Value  interface{}
+}
+
+func (g Gt) Build(b *Builder) {
+	buildComparison(b, g.Column, " > ", g.Value)
+}
+
+// Gte renders "column >= value".
+type Gte struct {
+	Column string
+	Value  interface{}
+}
+
+func (g Gte) Build(b *Builder) {
+	buildComparison(b, g.Column, " >= ", g.Value)
+}
+
+// Lt renders "column < value".
+type Lt struct {
+	Column string
+	Value  interface{}
+}
+
+func (l Lt) Build(b *Builder) {
+	buildComparison(b, l.Column, " < ", l.Value)
+}
+
+// Lte renders "column <= value".
+type Lte struct {
+	Column string
+	Value  interface{}
+}
+
+func (l Lte) Build(b *Builder) {
+	buildComparison(b, l.Column, " <= ", l.Value)
+}
+
+// Like renders "column LIKE pattern".
+type Like struct {
+	Column  string
+	Pattern string
+}
+
+func (l Like) Build(b *Builder) {
+	buildComparison(b, l.Column, " LIKE ", l.Pattern)
+}
+
+// In renders "column IN (values...)". An empty Values renders a condition that is always false.
+type In struct {
+	Column string
+	Values []interface{}
+}
+
+func (in In) Build(b *Builder) {
+	if len(in.Values) == 0 {
+		b.WriteString("1 = 0")
+		return
+	}
+	b.WriteQuoted(in.Column)
+	b.WriteString(" IN (")
+	for i, v := range in.Values {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.AddVar(v)
+	}
+	b.WriteString(")")
+}
+
+// Between renders "column BETWEEN from AND to".
+type Between struct {
+	Column string
+	From   interface{}
+	To     interface{}
+}
+
+func (bt Between) Build(b *Builder) {
+	b.WriteQuoted(bt.Column)
+	b.WriteString(" BETWEEN ")
+	b.AddVar(bt.From)
+	b.WriteString(" AND ")
+	b.AddVar(bt.To)
+}
+
+// IsNull renders "column IS NULL".
+type IsNull struct {
+	Column string
+}
+
+func (n IsNull) Build(b *Builder) {
+	b.WriteQuoted(n.Column)
+	b.WriteString(" IS NULL")
+}
+
+type andExpr []Expression
+
+// And joins the expressions with AND.
+func And(exprs ...Expression) Expression {
+	return andExpr(exprs)
+}
+
+func (a andExpr) Build(b *Builder) {
+	buildJoined(b, " AND ", a)
+}
+
+type orExpr []Expression
+
+// Or joins the expressions with OR.
+func Or(exprs ...Expression) Expression {
+	return orExpr(exprs)
+}
+
+func (o orExpr) Build(b *Builder) {
+	buildJoined(b, " OR ", o)
+}
+
+type notExpr struct {
+	expr Expression
+}
+
+// Not negates the expression.
+func Not(expr Expression) Expression {
+	return notExpr{expr: expr}
+}
+
+func (n notExpr) Build(b *Builder) {
+	if IsEmpty(n.expr) {
+		return
+	}
+	b.WriteString("NOT (")
+	n.expr.Build(b)
+	b.WriteString(")")
+}
+
+// Map converts a column-value map into equality conditions joined with AND, in column order.
+// Slice values other than []byte become IN conditions.
+func Map(m map[string]interface{}) Expression {
+	columns := make([]string, 0, len(m))
+	for column := range m {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	exprs := make(andExpr, 0, len(columns))
+	for _, column := range columns {
+		exprs = append(exprs, columnCondition(column, m[column]))
+	}
+	return exprs
+}
+
+// Struct converts the non-zero fields of a model, a struct or pointer to struct, into
+// equality conditions joined with AND. Columns are named by the fields' db tags, or by
+// the field names when untagged.
+func Struct(model interface{}) Expression {
+	value := reflect.Indirect(reflect.ValueOf(model))
+	fields := schema.ColumnFields(value.Type())
+
+	exprs := make(andExpr, 0, len(fields))
+	for _, field := range fields {
+		fieldValue := value.FieldByIndex(field.Index)
+		if fieldValue.IsZero() {
+			continue
+		}
+		exprs = append(exprs, columnCondition(schema.ColumnName(field), fieldValue.Interface()))
+	}
+	return exprs
+}
+
+func columnCondition(column string, value interface{}) Expression {
+	if _, ok := value.(driver.Valuer); ok {
+		return Eq{Column: column, Value: value}
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).Interface()
+		}
+		return In{Column: column, Values: values}
+	}
+	return Eq{Column: column, Value: value}
+}
+
+func buildComparison(b *Builder, column, op string, value interface{}) {
+	b.WriteQuoted(column)
+	b.WriteString(op)
+	b.AddVar(value)
+}
+
+// buildJoined writes exprs separated by sep, parenthesizing nested AND/OR groups
+// and skipping empty ones.
+func buildJoined(b *Builder, sep string, exprs []Expression) {
+	written := 0
+	for _, expr := range exprs {
+		if IsEmpty(expr) {
+			continue
+		}
+		if written > 0 {
+			b.WriteString(sep)
+		}
+		written++
+		if isGroup(expr) {
+			b.WriteString("(")
+			expr.Build(b)
+			b.WriteString(")")
+		} else {
+			expr.Build(b)
+		}
+	}
+}
+
+func isGroup(expr Expression) bool {
+	switch e := expr.(type) {
+	case andExpr:
+		return len(e) > 1
+	case orExpr:
+		return len(e) > 1
+	}
+	return false
+}
+
+// IsEmpty reports whether expr is an AND/OR group, or the negation of one, that renders no condition.
+func IsEmpty(expr Expression) bool {
+	var exprs []Expression
+	switch e := expr.(type) {
+	case andExpr:
+		exprs = e
+	case orExpr:
+		exprs = e
+	case notExpr:
+		return IsEmpty(e.expr)
+	default:
+		return false
+	}
+	for _, sub := range exprs {
+		if !IsEmpty(sub) {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-labx/orm/dialect"
@@ -19,20 +20,59 @@ type DB struct {
 	dialect    dialect.Dialect
 	dsn        string
 	dataSource *DataSource
+	callbacks  *session.Callbacks
+	tenants    atomic.Pointer[tenantPools]
+
+	replicaSources []*DataSource
+	policy         Policy
+	healthCheck    time.Duration
+	replicas       *replicaSet
 }
 
-// NewDB creates a new ORM DB instance
-func NewDB(d *DataSource) (*DB, error) {
+// NewDB creates a new ORM DB instance.
+// Plain SELECT queries are served by the replicas given with WithReplicas when any is
+// healthy, everything else by the primary DataSource d.
+func NewDB(d *DataSource, options ...DBOption) (*DB, error) {
+	db, dial, err := openDB(d)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := &DB{
+		db:         db,
+		dialect:    dial,
+		dsn:        d.DSN(),
+		dataSource: d,
+		callbacks:  session.NewCallbacks(),
+	}
+	for _, option := range options {
+		option(instance)
+	}
+
+	if len(instance.replicaSources) > 0 {
+		if instance.replicas, err = openReplicas(instance.replicaSources, instance.policy, instance.healthCheck); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	logger.Info("Connect database success")
+	return instance, nil
+}
+
+// openDB opens and pings a connection pool for the DataSource and looks up its dialect.
+func openDB(d *DataSource) (*sql.DB, dialect.Dialect, error) {
 	dsn := d.DSN()
 	db, err := sql.Open(string(d.Driver), dsn)
 	if err != nil {
 		logger.Error(err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err = db.Ping(); err != nil {
 		logger.Error(err)
-		return nil, err
+		_ = db.Close()
+		return nil, nil, err
 	}
 
 	// make sure the specific dialect exists
@@ -40,21 +80,34 @@ func NewDB(d *DataSource) (*DB, error) {
 	if !ok {
 		err = fmt.Errorf("dialect %s Not Found", d.Driver)
 		logger.Error(err)
-		return nil, err
+		_ = db.Close()
+		return nil, nil, err
 	}
-
-	logger.Info("Connect database success")
-	return &DB{
-		db:         db,
-		dialect:    dial,
-		dsn:        dsn,
-		dataSource: d,
-	}, nil
+	return db, dial, nil
 }
 
 // NewSession creates a new session with the current database connection
 func (d *DB) NewSession() *session.Session {
-	return session.New(d.db, d.dialect)
+	s := session.New(d.db, d.dialect)
+	s.SetCallbacks(d.callbacks)
+	if d.replicas != nil {
+		s.SetReplicas(d.replicas)
+	}
+	return s
+}
+
+// Callback returns the callback processors shared by every session of the DB.
+func (d *DB) Callback() *session.Callbacks {
+	return d.callbacks
+}
+
+// Use initializes the given plugin against the DB.
+func (d *DB) Use(plugin Plugin) error {
+	if err := plugin.Initialize(d); err != nil {
+		logger.Error(err)
+		return err
+	}
+	return nil
 }
 
 // EnableDebug sets the debug flag to true
@@ -67,8 +120,14 @@ func (d *DB) DisableDebug() {
 	d.debug = false
 }
 
-// Close closes the connection to the database
+// Close closes the connection to the database, along with any tenant and replica connections
 func (d *DB) Close() error {
+	if tenants := d.tenants.Swap(nil); tenants != nil {
+		tenants.close()
+	}
+	if d.replicas != nil {
+		d.replicas.close()
+	}
 	return d.db.Close()
 }
 
@@ -122,8 +181,13 @@ func (d *DB) Exec(query string, args ...any) (sql.Result, error) {
 
 // ExecContext executes a query without returning any rows.
 // The args are for any placeholder parameters in the query.
+// It runs on the connection of the tenant resolved from ctx, see SetTenantResolver.
 func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	return d.NewSession().Raw(query, args...).ExecContext(ctx)
+	s, err := d.NewSessionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Raw(query, args...).ExecContext(ctx)
 }
 
 // Query executes a query that returns rows, typically a SELECT.
@@ -134,8 +198,13 @@ func (d *DB) Query(query string, args ...any) (*sql.Rows, error) {
 
 // QueryContext executes a query that returns rows, typically a SELECT.
 // The args are for any placeholder parameters in the query.
+// It runs on the connection of the tenant resolved from ctx, see SetTenantResolver.
 func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return d.NewSession().Raw(query, args...).QueryContext(ctx)
+	s, err := d.NewSessionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Raw(query, args...).QueryContext(ctx)
 }
 
 // QueryRow executes a query that is expected to return at most one row.
@@ -154,8 +223,13 @@ func (d *DB) QueryRow(query string, args ...any) *sql.Row {
 // If the query selects no rows, the *Row's Scan will return ErrNoRows.
 // Otherwise, the *Row's Scan scans the first selected row and discards
 // the rest.
+// It runs on the connection of the tenant resolved from ctx, see SetTenantResolver.
 func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
-	return d.NewSession().Raw(query, args...).QueryRowContext(ctx)
+	s, err := d.NewSessionContext(ctx)
+	if err != nil {
+		return session.ErrRow(err)
+	}
+	return s.Raw(query, args...).QueryRowContext(ctx)
 }
 
 // Conn returns a single connection by either opening a new connection
@@ -17,6 +17,12 @@ type Dialect interface {
 	IsTableExistSQL(tableName string) string
 
 	DropTableSQL(tableName string) string
+
+	// Quote returns the identifier quoted for use in SQL
+	Quote(name string) string
+
+	// BindVar returns the placeholder of the i-th (1-based) bound argument
+	BindVar(i int) string
 }
 
 // RegisterDialect registers a new SQL dialect
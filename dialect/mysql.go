@@ -3,6 +3,7 @@ package dialect
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -49,3 +50,15 @@ func (mysql *MysqlDialect) IsTableExistSQL(tableName string) string {
 func (mysql *MysqlDialect) DropTableSQL(tableName string) string {
 	return fmt.Sprintf("DROP TABLE IF EXISTS `%s`;", tableName)
 }
+
+func (mysql *MysqlDialect) Quote(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+func (mysql *MysqlDialect) BindVar(i int) string {
+	return "?"
+}
@@ -0,0 +1,125 @@
+// Package stubdriver provides an in-memory database/sql driver for tests
+package stubdriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+)
+
+// Connector answers every query with the same columns and rows.
+type Connector struct {
+	Columns    []string
+	Rows       [][]driver.Value
+	ConnectErr error // ConnectErr, when set, fails every connection attempt.
+
+	mu      sync.Mutex
+	queries []string
+}
+
+// DB returns a *sql.DB backed by the connector.
+func (c *Connector) DB() *sql.DB {
+	return sql.OpenDB(c)
+}
+
+// Queries returns the SQL of every query and exec run so far.
+func (c *Connector) Queries() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.queries...)
+}
+
+func (c *Connector) record(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+}
+
+func (c *Connector) Connect(context.Context) (driver.Conn, error) {
+	if c.ConnectErr != nil {
+		return nil, c.ConnectErr
+	}
+	return &conn{connector: c}, nil
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return c
+}
+
+func (c *Connector) Open(string) (driver.Conn, error) {
+	return c.Connect(context.Background())
+}
+
+type conn struct {
+	connector *Connector
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{connector: c.connector, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+type tx struct{}
+
+func (tx) Commit() error {
+	return nil
+}
+
+func (tx) Rollback() error {
+	return nil
+}
+
+type stmt struct {
+	connector *Connector
+	query     string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec([]driver.Value) (driver.Result, error) {
+	s.connector.record(s.query)
+	return driver.RowsAffected(0), nil
+}
+
+func (s *stmt) Query([]driver.Value) (driver.Rows, error) {
+	s.connector.record(s.query)
+	return &rows{columns: s.connector.Columns, values: s.connector.Rows}, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,25 @@
+package orm
+
+import "github.com/go-labx/orm/session"
+
+// Iterate executes the SQL query in the session and calls fn with every row scanned
+// into a T, which must be a struct type. Iteration stops at the first error returned
+// by fn or encountered while reading rows.
+func Iterate[T any](s *session.Session, fn func(T) error) error {
+	rows, err := s.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item T
+		if err = rows.Scan(&item); err != nil {
+			return err
+		}
+		if err = fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,49 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-labx/orm/dialect"
+	"github.com/go-labx/orm/internal/stubdriver"
+	"github.com/go-labx/orm/session"
+)
+
+type iterUser struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestIterate(t *testing.T) {
+	mysql, _ := dialect.GetDialect(MySQL)
+	connector := &stubdriver.Connector{
+		Columns: []string{"id", "name"},
+		Rows:    [][]driver.Value{{int64(1), "tom"}, {int64(2), "amy"}, {int64(3), "bob"}},
+	}
+
+	var users []iterUser
+	s := session.New(connector.DB(), mysql)
+	err := Iterate(s.Raw("SELECT id, name FROM user"), func(u iterUser) error {
+		users = append(users, u)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []iterUser{{1, "tom"}, {2, "amy"}, {3, "bob"}}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("expected %v, got %v", expected, users)
+	}
+
+	errStop := errors.New("stop")
+	count := 0
+	err = Iterate(s.Raw("SELECT id, name FROM user"), func(u iterUser) error {
+		count++
+		return errStop
+	})
+	if err != errStop || count != 1 {
+		t.Errorf("expected iteration to stop at the first error, got %v after %d rows", err, count)
+	}
+}
@@ -0,0 +1,6 @@
+package orm
+
+import "github.com/go-labx/orm/session"
+
+// Named holds the values of named parameters for Session.Raw, referenced as :name in the SQL query.
+type Named = session.Named
@@ -0,0 +1,7 @@
+package orm
+
+// Plugin extends a DB with cross-cutting behaviour, typically by registering
+// callbacks through DB.Callback.
+type Plugin interface {
+	Initialize(*DB) error
+}
@@ -0,0 +1,173 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-labx/orm/logger"
+	"github.com/go-labx/orm/session"
+)
+
+// Policy decides which healthy replica serves a read.
+type Policy int
+
+const (
+	RoundRobin Policy = iota // RoundRobin cycles through the replicas in order.
+	Random                   // Random picks a replica at random.
+	LeastConn                // LeastConn picks the replica with the fewest connections in use.
+)
+
+// DefaultHealthCheckInterval is how often replicas are pinged unless WithHealthCheck is given.
+const DefaultHealthCheckInterval = 10 * time.Second
+
+// DBOption configures a DB created by NewDB.
+type DBOption func(*DB)
+
+// WithReplicas sets the read replicas of the DB.
+func WithReplicas(sources ...*DataSource) DBOption {
+	return func(d *DB) {
+		d.replicaSources = sources
+	}
+}
+
+// WithPolicy sets how reads are balanced across replicas
+func WithPolicy(policy Policy) DBOption {
+	return func(d *DB) {
+		d.policy = policy
+	}
+}
+
+// WithHealthCheck sets how often replicas are pinged to detect failures and recoveries.
+func WithHealthCheck(interval time.Duration) DBOption {
+	return func(d *DB) {
+		d.healthCheck = interval
+	}
+}
+
+// UsePrimary forces the queries of a session to the primary, e.g. right after a write.
+func UsePrimary() session.Clause {
+	return session.UsePrimary()
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet balances reads across the healthy replicas and pings them periodically.
+type replicaSet struct {
+	replicas []*replica
+	policy   Policy
+	next     atomic.Uint32
+	stop     chan struct{}
+	closing  sync.Once
+}
+
+// openReplicas opens every replica. A replica that cannot be reached yet is kept and
+// marked unhealthy, so that the health check can bring it back.
+func openReplicas(sources []*DataSource, policy Policy, interval time.Duration) (*replicaSet, error) {
+	set := &replicaSet{policy: policy, stop: make(chan struct{})}
+	for _, ds := range sources {
+		db, err := sql.Open(string(ds.Driver), ds.DSN())
+		if err != nil {
+			logger.Error(err)
+			set.close()
+			return nil, err
+		}
+		r := &replica{db: db}
+		set.replicas = append(set.replicas, r)
+		if err = db.Ping(); err != nil {
+			logger.Error(err)
+			continue
+		}
+		r.healthy.Store(true)
+	}
+
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+	go set.watch(interval)
+	return set, nil
+}
+
+// Pick returns the connection of a healthy replica, or nil when none is healthy.
+func (s *replicaSet) Pick() *sql.DB {
+	healthy := make([]*replica, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch s.policy {
+	case Random:
+		return healthy[rand.Intn(len(healthy))].db
+	case LeastConn:
+		least := healthy[0]
+		for _, r := range healthy[1:] {
+			if r.db.Stats().InUse < least.db.Stats().InUse {
+				least = r
+			}
+		}
+		return least.db
+	default:
+		return healthy[(s.next.Add(1)-1)%uint32(len(healthy))].db
+	}
+}
+
+// MarkDown marks the replica of db unhealthy until the health check finds it reachable again.
+func (s *replicaSet) MarkDown(db *sql.DB) {
+	for _, r := range s.replicas {
+		if r.db == db {
+			r.healthy.Store(false)
+		}
+	}
+}
+
+// watch pings every replica at each interval until the set is closed.
+func (s *replicaSet) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.check(interval)
+		}
+	}
+}
+
+// check updates the health of every replica.
+func (s *replicaSet) check(timeout time.Duration) {
+	for _, r := range s.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := r.db.PingContext(ctx)
+		cancel()
+		if healthy := err == nil; r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				logger.Info("Replica recovered")
+			} else {
+				logger.Error(err)
+			}
+		}
+	}
+}
+
+// close stops the health check and closes every replica. Only the first call has an effect.
+func (s *replicaSet) close() {
+	s.closing.Do(func() {
+		close(s.stop)
+		for _, r := range s.replicas {
+			_ = r.db.Close()
+		}
+	})
+}
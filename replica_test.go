@@ -0,0 +1,138 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/go-labx/orm/dialect"
+	"github.com/go-labx/orm/internal/stubdriver"
+	"github.com/go-labx/orm/session"
+)
+
+func newTestReplicaSet(policy Policy, healthy ...bool) *replicaSet {
+	set := &replicaSet{policy: policy}
+	for _, h := range healthy {
+		r := &replica{db: &sql.DB{}}
+		r.healthy.Store(h)
+		set.replicas = append(set.replicas, r)
+	}
+	return set
+}
+
+func TestReplicaSetPick(t *testing.T) {
+	set := newTestReplicaSet(RoundRobin, true, false, true)
+	first, second, third := set.Pick(), set.Pick(), set.Pick()
+	if first != set.replicas[0].db || second != set.replicas[2].db || third != first {
+		t.Error("expected round robin to cycle through healthy replicas only")
+	}
+
+	for _, policy := range []Policy{RoundRobin, Random, LeastConn} {
+		set = newTestReplicaSet(policy, false, true)
+		if db := set.Pick(); db != set.replicas[1].db {
+			t.Errorf("policy %d: expected the only healthy replica", policy)
+		}
+	}
+
+	set = newTestReplicaSet(Random, false, false)
+	if db := set.Pick(); db != nil {
+		t.Error("expected no replica when all are unhealthy")
+	}
+
+	set = newTestReplicaSet(RoundRobin, true, true)
+	set.next.Store(math.MaxUint32)
+	if set.Pick() != set.replicas[1].db || set.Pick() != set.replicas[0].db {
+		t.Error("expected round robin to wrap around the counter")
+	}
+}
+
+// newReplicaTestDB returns a DB on a stub primary with a single healthy replica.
+func newReplicaTestDB(t *testing.T, connector *stubdriver.Connector) (*DB, *stubdriver.Connector, *replica) {
+	mysql, _ := dialect.GetDialect(MySQL)
+	primary := &stubdriver.Connector{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(1)}}}
+	set := &replicaSet{stop: make(chan struct{})}
+	r := &replica{db: connector.DB()}
+	r.healthy.Store(true)
+	set.replicas = append(set.replicas, r)
+	d := &DB{db: primary.DB(), dialect: mysql, callbacks: session.NewCallbacks(), replicas: set}
+	t.Cleanup(func() { _ = d.Close() })
+	return d, primary, r
+}
+
+func TestReplicaRouting(t *testing.T) {
+	replica := &stubdriver.Connector{Columns: []string{"id"}}
+	d, primary, _ := newReplicaTestDB(t, replica)
+
+	for _, query := range []string{
+		"SELECT id FROM user",
+		"SELECT id FROM user FOR UPDATE",
+		"INSERT INTO user (name) VALUES ('tom') RETURNING id",
+		"CALL refresh()",
+	} {
+		rows, err := d.NewSession().Raw(query).Query()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = rows.Close()
+	}
+
+	if got := replica.Queries(); len(got) != 1 || got[0] != "SELECT id FROM user " {
+		t.Errorf("expected only the plain SELECT on the replica, got %v", got)
+	}
+	if got := primary.Queries(); len(got) != 3 {
+		t.Errorf("expected the other statements on the primary, got %v", got)
+	}
+}
+
+func TestReplicaFailoverContext(t *testing.T) {
+	down := &stubdriver.Connector{ConnectErr: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	d, primary, r := newReplicaTestDB(t, down)
+
+	ctx, cancel := context.WithTimeout(context.Background(), -1)
+	defer cancel()
+	if _, err := d.NewSession().Raw("SELECT id FROM user").QueryContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the caller's deadline, got %v", err)
+	}
+	if !r.healthy.Load() {
+		t.Error("expected the caller's deadline not to mark the replica down")
+	}
+	if got := primary.Queries(); len(got) != 0 {
+		t.Errorf("expected no retry on the primary, got %v", got)
+	}
+}
+
+func TestReplicaFailover(t *testing.T) {
+	down := &stubdriver.Connector{ConnectErr: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	d, primary, r := newReplicaTestDB(t, down)
+
+	rows, err := d.NewSession().Raw("SELECT id FROM user").Query()
+	if err != nil {
+		t.Fatalf("expected the query to be retried on the primary, got %v", err)
+	}
+	_ = rows.Close()
+	if r.healthy.Load() {
+		t.Error("expected the unreachable replica to be marked down")
+	}
+
+	r.healthy.Store(true)
+	var id int64
+	if err = d.NewSession().Raw("SELECT id FROM user").QueryRow().Scan(&id); err != nil || id != 1 {
+		t.Fatalf("expected the row from the primary, got %d, %v", id, err)
+	}
+	if r.healthy.Load() {
+		t.Error("expected the unreachable replica to be marked down")
+	}
+	if got := primary.Queries(); len(got) != 2 {
+		t.Errorf("expected both queries on the primary, got %v", got)
+	}
+}
+
+func TestReplicaSetCloseTwice(t *testing.T) {
+	set := &replicaSet{stop: make(chan struct{})}
+	set.close()
+	set.close()
+}
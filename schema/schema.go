@@ -30,7 +30,7 @@ func (s *Schema) GetField(name string) *Field {
 
 // Parse is a function that takes a destination interface and a dialect, and returns a pointer to a Schema.
 // It first gets the type of the model from the destination interface, and initializes a new Schema with the model, its name, and an empty fieldMap.
-// Then, it iterates over the fields of the model. If a field is not anonymous and is exported, it creates a new Field with the name of the model field and its type, as determined by the dialect.
+// Then, it iterates over the fields of the model returned by ColumnFields, creating for each a new Field named after its column, as returned by ColumnName, and with its type as determined by the dialect.
 // If the model field has a tag "orm", it also sets the Tag of the Field to the value of this tag.
 // Finally, it adds the new Field to the Fields slice of the Schema, its name to the FieldNames slice, and a mapping from its name to the Field itself to the fieldMap.
 // After all fields have been processed, it returns the pointer to the Schema.
@@ -42,20 +42,40 @@ func Parse(dest interface{}, d dialect.Dialect) *Schema {
 		fieldMap: make(map[string]*Field),
 	}
 
-	for i := 0; i < modelType.NumField(); i++ {
-		p := modelType.Field(i)
-		if !p.Anonymous && ast.IsExported(p.Name) {
-			field := &Field{
-				Name: p.Name,
-				Type: d.DataTypeOf(reflect.Indirect(reflect.New(p.Type))),
-			}
-			if v, ok := p.Tag.Lookup("orm"); ok {
-				field.Tag = v
-			}
-			schema.Fields = append(schema.Fields, field)
-			schema.FieldNames = append(schema.FieldNames, p.Name)
-			schema.fieldMap[p.Name] = field
+	for _, p := range ColumnFields(modelType) {
+		field := &Field{
+			Name: ColumnName(p),
+			Type: d.DataTypeOf(reflect.Indirect(reflect.New(p.Type))),
 		}
+		if v, ok := p.Tag.Lookup("orm"); ok {
+			field.Tag = v
+		}
+		schema.Fields = append(schema.Fields, field)
+		schema.FieldNames = append(schema.FieldNames, field.Name)
+		schema.fieldMap[field.Name] = field
 	}
 	return schema
 }
+
+// ColumnName returns the column a struct field maps to: the value of its db tag when present,
+// its name otherwise.
+func ColumnName(field reflect.StructField) string {
+	if v, ok := field.Tag.Lookup("db"); ok && v != "" {
+		return v
+	}
+	return field.Name
+}
+
+// ColumnFields returns the exported, non-anonymous fields of a struct type that map to columns,
+// skipping fields tagged db:"-".
+func ColumnFields(typ reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < typ.NumField(); i++ {
+		p := typ.Field(i)
+		if p.Anonymous || !ast.IsExported(p.Name) || p.Tag.Get("db") == "-" {
+			continue
+		}
+		fields = append(fields, p)
+	}
+	return fields
+}
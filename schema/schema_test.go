@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/go-labx/orm/dialect"
@@ -22,3 +23,33 @@ func TestParse(t *testing.T) {
 		t.Fatal("failed to parse primary key")
 	}
 }
+
+type Account struct {
+	ID        int   `db:"id"`
+	CreatedAt int64 `db:"created_at"`
+	Nickname  string
+	Secret    string `db:"-"`
+	internal  string
+}
+
+func TestColumnFields(t *testing.T) {
+	var columns []string
+	for _, field := range ColumnFields(reflect.TypeOf(Account{})) {
+		columns = append(columns, ColumnName(field))
+	}
+	expected := []string{"id", "created_at", "Nickname"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Fatalf("expected columns %v, got %v", expected, columns)
+	}
+}
+
+func TestParseColumnNames(t *testing.T) {
+	schema := Parse(&Account{}, TestDial)
+	expected := []string{"id", "created_at", "Nickname"}
+	if !reflect.DeepEqual(schema.FieldNames, expected) {
+		t.Fatalf("expected columns %v, got %v", expected, schema.FieldNames)
+	}
+	if schema.GetField("id") == nil || schema.GetField("ID") != nil {
+		t.Fatal("expected fields to be looked up by column name")
+	}
+}
@@ -0,0 +1,312 @@
+package session
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-labx/orm/dialect"
+)
+
+// Named holds the values of named parameters, referenced as :name in the SQL query.
+type Named map[string]interface{}
+
+// namedArg returns the source of named parameter values when args consist of a single
+// Named or struct value, which is used only if the SQL query references named parameters.
+func namedArg(args []interface{}) (interface{}, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+	if named, ok := args[0].(Named); ok {
+		return named, true
+	}
+	if _, ok := args[0].(driver.Valuer); ok {
+		return nil, false
+	}
+	value := reflect.Indirect(reflect.ValueOf(args[0]))
+	if value.Kind() != reflect.Struct || value.Type().PkgPath() == "time" {
+		return nil, false
+	}
+	return args[0], true
+}
+
+// lookupNamed returns the value of the named parameter from a Named map, or from the
+// struct field mapped to the column of that name, as Rows.Scan maps columns to fields.
+func lookupNamed(source interface{}, name string) (interface{}, bool) {
+	if named, ok := source.(Named); ok {
+		v, ok := named[name]
+		return v, ok
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(source))
+	index := columnFields(value.Type(), []string{name})[0]
+	if index == nil {
+		return nil, false
+	}
+	return value.FieldByIndex(index).Interface(), true
+}
+
+// bindNamed rewrites :name parameters into "?" placeholders and returns the values in
+// placeholder order. Repeated names bind the value again; "::" casts, quoted literals
+// and comments are left untouched.
+func bindNamed(sql string, source interface{}) (string, []interface{}, error) {
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	for i := 0; i < len(sql); {
+		if end := skipLiteral(sql, i); end > i {
+			b.WriteString(sql[i:end])
+			i = end
+			continue
+		}
+		if sql[i] != ':' {
+			b.WriteByte(sql[i])
+			i++
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == ':' {
+			b.WriteString("::")
+			i += 2
+			continue
+		}
+
+		end := i + 1
+		for end < len(sql) && isNameByte(sql[end], end == i+1) {
+			end++
+		}
+		if end == i+1 {
+			b.WriteByte(':')
+			i++
+			continue
+		}
+
+		name := sql[i+1 : end]
+		value, ok := lookupNamed(source, name)
+		if !ok {
+			return "", nil, fmt.Errorf("named parameter %s not found", name)
+		}
+		b.WriteByte('?')
+		args = append(args, value)
+		i = end
+	}
+	return b.String(), args, nil
+}
+
+// expandSlices rewrites each "?" bound to a slice (other than []byte or a driver.Valuer)
+// into one placeholder per element. An empty slice is only accepted as the whole list of
+// a plain "IN (?)", where it renders NULL so that no rows match; anywhere else, NOT IN
+// included, it is an error since NULL would not behave like an empty list there.
+// The query is left as is when its placeholders do not line up with args.
+func expandSlices(sql string, args []interface{}) (string, []interface{}, error) {
+	expand := false
+	for _, arg := range args {
+		if isExpandable(arg) {
+			expand = true
+			break
+		}
+	}
+	if !expand {
+		return sql, args, nil
+	}
+
+	var (
+		b    strings.Builder
+		vars []interface{}
+		n    int
+	)
+	for i := 0; i < len(sql); {
+		if end := skipLiteral(sql, i); end > i {
+			b.WriteString(sql[i:end])
+			i = end
+			continue
+		}
+		if sql[i] != '?' {
+			b.WriteByte(sql[i])
+			i++
+			continue
+		}
+		if n >= len(args) {
+			return sql, args, nil
+		}
+
+		arg := args[n]
+		n++
+		i++
+		if !isExpandable(arg) {
+			b.WriteByte('?')
+			vars = append(vars, arg)
+			continue
+		}
+		value := reflect.ValueOf(arg)
+		if value.Len() == 0 {
+			if !isPlainIn(b.String(), sql[i:]) {
+				return "", nil, fmt.Errorf("empty slice bound outside a plain IN (?) or under NOT in %q", sql)
+			}
+			b.WriteString("NULL")
+			continue
+		}
+		for j := 0; j < value.Len(); j++ {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('?')
+			vars = append(vars, value.Index(j).Interface())
+		}
+	}
+	if n != len(args) {
+		return sql, args, nil
+	}
+	return b.String(), vars, nil
+}
+
+// isPlainIn reports whether the placeholder between before and after is the only item
+// of an "IN (...)" list that no NOT applies to, as in NOT IN or NOT (id IN (...)).
+func isPlainIn(before, after string) bool {
+	if !strings.HasPrefix(strings.TrimLeft(after, " \t\r\n"), ")") {
+		return false
+	}
+	tokens := sqlTokens(before)
+	n := len(tokens)
+	return n >= 2 && tokens[n-1] == "(" && tokens[n-2] == "IN" && !negated(tokens[:n-2])
+}
+
+// predicateBoundaries end the predicate a NOT before them would apply to.
+var predicateBoundaries = map[string]bool{
+	"AND": true, "OR": true, ",": true, "WHERE": true, "ON": true, "HAVING": true,
+	"WHEN": true, "THEN": true, "ELSE": true, "SELECT": true,
+}
+
+// negated reports whether a NOT applies to the predicate that ends the tokens, either
+// directly or through one of the parenthesized groups enclosing it.
+func negated(tokens []string) bool {
+	// skipping is set once the predicate ended within the current group, whose
+	// remaining tokens are then skipped up to its opening parenthesis.
+	skipping := false
+	for i := len(tokens) - 1; i >= 0; i-- {
+		switch token := tokens[i]; {
+		case token == ")":
+			// skip a sibling group
+			for depth := 1; depth > 0 && i > 0; {
+				i--
+				switch tokens[i] {
+				case ")":
+					depth++
+				case "(":
+					depth--
+				}
+			}
+		case token == "(":
+			skipping = false
+		case skipping:
+		case token == "NOT":
+			return true
+		case predicateBoundaries[token]:
+			skipping = true
+		}
+	}
+	return false
+}
+
+// sqlTokens splits SQL into upper-cased words and single punctuation characters,
+// leaving out whitespace, quoted literals and comments.
+func sqlTokens(sql string) []string {
+	var tokens []string
+	for i := 0; i < len(sql); {
+		if end := skipLiteral(sql, i); end > i {
+			i = end
+			continue
+		}
+		c := sql[i]
+		switch {
+		case isNameByte(c, false):
+			end := i
+			for end < len(sql) && isNameByte(sql[end], false) {
+				end++
+			}
+			tokens = append(tokens, strings.ToUpper(sql[i:end]))
+			i = end
+			continue
+		case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			tokens = append(tokens, string(c))
+		}
+		i++
+	}
+	return tokens
+}
+
+func isExpandable(arg interface{}) bool {
+	if _, ok := arg.(driver.Valuer); ok {
+		return false
+	}
+	value := reflect.ValueOf(arg)
+	return value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8
+}
+
+// rebind rewrites "?" placeholders into the dialect's bind variables.
+func rebind(d dialect.Dialect, sql string) string {
+	if d.BindVar(1) == "?" {
+		return sql
+	}
+
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(sql); {
+		if end := skipLiteral(sql, i); end > i {
+			b.WriteString(sql[i:end])
+			i = end
+			continue
+		}
+		if sql[i] == '?' {
+			n++
+			b.WriteString(d.BindVar(n))
+		} else {
+			b.WriteByte(sql[i])
+		}
+		i++
+	}
+	return b.String()
+}
+
+// skipLiteral returns the index just past the quoted literal or comment starting at i,
+// or i when none starts there.
+func skipLiteral(sql string, i int) int {
+	switch c := sql[i]; c {
+	case '\'', '"', '`':
+		for j := i + 1; j < len(sql); j++ {
+			switch sql[j] {
+			case '\\':
+				if c != '`' {
+					j++
+				}
+			case c:
+				if j+1 < len(sql) && sql[j+1] == c {
+					j++
+					continue
+				}
+				return j + 1
+			}
+		}
+		return len(sql)
+	case '-':
+		if strings.HasPrefix(sql[i:], "--") {
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				return i + end + 1
+			}
+			return len(sql)
+		}
+	case '/':
+		if strings.HasPrefix(sql[i:], "/*") {
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				return i + 2 + end + 2
+			}
+			return len(sql)
+		}
+	}
+	return i
+}
+
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+}
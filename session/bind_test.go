@@ -0,0 +1,207 @@
+package session
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/go-labx/orm/dialect"
+)
+
+type dollarDialect struct {
+	dialect.Dialect
+}
+
+func (d dollarDialect) BindVar(i int) string {
+	return "$" + strconv.Itoa(i)
+}
+
+func TestBindNamed(t *testing.T) {
+	type filter struct {
+		Tenant int `db:"tenant"`
+		Name   string
+		Kind   string `db:""`
+		Secret string `db:"-"`
+	}
+
+	tests := []struct {
+		name     string
+		sql      string
+		source   interface{}
+		expected string
+		args     []interface{}
+	}{
+		{
+			"Repeated names",
+			"SELECT * FROM t WHERE a = :tenant AND b = :tenant",
+			Named{"tenant": 1},
+			"SELECT * FROM t WHERE a = ? AND b = ?",
+			[]interface{}{1, 1},
+		},
+		{
+			"Casts and literals",
+			"SELECT a::text, ':skip', \"x:y\" FROM t -- :note\nWHERE a = :name /* :c */",
+			Named{"name": "tom"},
+			"SELECT a::text, ':skip', \"x:y\" FROM t -- :note\nWHERE a = ? /* :c */",
+			[]interface{}{"tom"},
+		},
+		{
+			"Struct tags",
+			"SELECT * FROM t WHERE a = :tenant AND b = :Name",
+			&filter{Tenant: 2, Name: "tom"},
+			"SELECT * FROM t WHERE a = ? AND b = ?",
+			[]interface{}{2, "tom"},
+		},
+		{
+			"Column names as in Rows.Scan",
+			"SELECT * FROM t WHERE a = :name AND b = :Kind",
+			filter{Name: "tom", Kind: "admin"},
+			"SELECT * FROM t WHERE a = ? AND b = ?",
+			[]interface{}{"tom", "admin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := bindNamed(tt.sql, tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("expected args %v, got %v", tt.args, args)
+			}
+		})
+	}
+
+	if _, _, err := bindNamed("SELECT :missing", Named{}); err == nil {
+		t.Error("expected missing named parameter to fail")
+	}
+	if _, _, err := bindNamed("SELECT :Secret", filter{}); err == nil {
+		t.Error("expected a field tagged db:\"-\" not to bind")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	sql := rebind(dollarDialect{}, "SELECT '?' FROM t WHERE a = ? AND b IN (?, ?)")
+	expected := "SELECT '?' FROM t WHERE a = $1 AND b IN ($2, $3)"
+	if sql != expected {
+		t.Errorf("expected %q, got %q", expected, sql)
+	}
+}
+
+func TestExpandSlices(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		args     []interface{}
+		expected string
+		vars     []interface{}
+	}{
+		{
+			"Slice",
+			"SELECT * FROM t WHERE a = ? AND id IN (?)",
+			[]interface{}{"x", []int{1, 2, 3}},
+			"SELECT * FROM t WHERE a = ? AND id IN (?, ?, ?)",
+			[]interface{}{"x", 1, 2, 3},
+		},
+		{
+			"Empty slice",
+			"SELECT * FROM t WHERE id IN (?) AND a = ?",
+			[]interface{}{[]string{}, "x"},
+			"SELECT * FROM t WHERE id IN (NULL) AND a = ?",
+			[]interface{}{"x"},
+		},
+		{
+			"Unrelated NOT",
+			"SELECT * FROM t WHERE a IS NOT NULL AND NOT (b = 1) AND id IN (?)",
+			[]interface{}{[]int{}},
+			"SELECT * FROM t WHERE a IS NOT NULL AND NOT (b = 1) AND id IN (NULL)",
+			nil,
+		},
+		{
+			"Lower case in",
+			"SELECT * FROM t WHERE id in ( ? )",
+			[]interface{}{[]int{}},
+			"SELECT * FROM t WHERE id in ( NULL )",
+			nil,
+		},
+		{
+			"Bytes and literals",
+			"SELECT '?' FROM t WHERE b = ? AND id IN (?)",
+			[]interface{}{[]byte("b"), []int{1}},
+			"SELECT '?' FROM t WHERE b = ? AND id IN (?)",
+			[]interface{}{[]byte("b"), 1},
+		},
+		{
+			"Mismatched placeholders",
+			"SELECT * FROM t WHERE id IN ($1)",
+			[]interface{}{[]int{1, 2}},
+			"SELECT * FROM t WHERE id IN ($1)",
+			[]interface{}{[]int{1, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars, err := expandSlices(tt.sql, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, sql)
+			}
+			if !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("expected vars %v, got %v", tt.vars, vars)
+			}
+		})
+	}
+}
+
+func TestExpandEmptySliceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"Not in", "SELECT * FROM t WHERE id NOT IN (?)"},
+		{"Negated group", "SELECT * FROM t WHERE NOT (id IN (?))"},
+		{"Negated predicate", "SELECT * FROM t WHERE NOT id IN (?)"},
+		{"Negated enclosing group", "SELECT * FROM t WHERE a = 1 AND NOT (b = 2 OR id IN (?))"},
+		{"Equality", "SELECT * FROM t WHERE id = ?"},
+		{"Mixed list", "SELECT * FROM t WHERE id IN (?, 3)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := expandSlices(tt.sql, []interface{}{[]int{}}); err == nil {
+				t.Errorf("expected empty slice in %q to fail", tt.sql)
+			}
+		})
+	}
+
+	// the error fails the statement built with Raw
+	mysql, _ := dialect.GetDialect("mysql")
+	s := New(nil, mysql).Raw("SELECT * FROM t WHERE id NOT IN (?)", []int{})
+	if stmt := s.statement(context.Background()); stmt.Error == nil {
+		t.Error("expected the statement to fail")
+	}
+}
+
+func TestRawComposition(t *testing.T) {
+	s := New(nil, dollarDialect{})
+	s.Raw("SELECT * FROM t WHERE tenant = :tenant", Named{"tenant": 7}).
+		Raw("AND id IN (?) AND kind = ?", []int{1, 2}, "a")
+
+	stmt := s.statement(context.Background())
+	expected := "SELECT * FROM t WHERE tenant = $1 AND id IN ($2, $3) AND kind = $4 "
+	if stmt.SQL != expected {
+		t.Errorf("expected %q, got %q", expected, stmt.SQL)
+	}
+	vars := []interface{}{7, 1, 2, "a"}
+	if !reflect.DeepEqual(stmt.Vars, vars) {
+		t.Errorf("expected vars %v, got %v", vars, stmt.Vars)
+	}
+}
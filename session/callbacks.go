@@ -0,0 +1,232 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-labx/orm/logger"
+)
+
+// Statement carries a single SQL execution through a callback chain.
+// Callbacks may inspect or rewrite SQL and Vars before the built-in executor runs,
+// and read Result, Rows, Row and Error after it.
+type Statement struct {
+	Context context.Context
+	Session *Session
+	SQL     string        // SQL query
+	Vars    []interface{} // Arguments for the SQL query
+	Result  sql.Result    // Result of a raw execution
+	Rows    *sql.Rows     // Rows of a query
+	Row     *sql.Row      // Row of a single-row query
+	Error   error         // First error reported by a callback or the executor
+
+	singleRow bool
+}
+
+// AddError records err on the statement, keeping the first error reported.
+func (stmt *Statement) AddError(err error) {
+	if err != nil && stmt.Error == nil {
+		stmt.Error = err
+	}
+}
+
+// defaultCallbacks runs the built-in executors for sessions created without callbacks.
+var defaultCallbacks = NewCallbacks()
+
+// errNoResult fails a statement whose callbacks neither produced a result nor reported an
+// error, e.g. when the built-in executor was replaced by a callback that does nothing.
+var errNoResult = errors.New("callback produced no result")
+
+// Callbacks holds the callback processors of a DB.
+type Callbacks struct {
+	processors map[string]*Processor
+}
+
+// NewCallbacks creates the callback processors with the built-in executors registered
+// as "orm:query" and "orm:raw".
+func NewCallbacks() *Callbacks {
+	cs := &Callbacks{
+		processors: map[string]*Processor{
+			"query": {name: "query"},
+			"raw":   {name: "raw"},
+		},
+	}
+	_ = cs.Query().Register("orm:query", queryCallback)
+	_ = cs.Raw().Register("orm:raw", rawCallback)
+	return cs
+}
+
+// Query returns the processor run by Query, QueryContext, QueryRow and QueryRowContext.
+func (cs *Callbacks) Query() *Processor {
+	return cs.processors["query"]
+}
+
+// Raw returns the processor run by Exec and ExecContext.
+func (cs *Callbacks) Raw() *Processor {
+	return cs.processors["raw"]
+}
+
+// Processor is an ordered chain of named callbacks.
+type Processor struct {
+	name      string
+	mu        sync.RWMutex
+	callbacks []*callback
+}
+
+type callback struct {
+	name    string
+	handler func(*Statement)
+}
+
+// Callback positions the next registration relative to an existing callback.
+type Callback struct {
+	processor *Processor
+	before    string
+	after     string
+}
+
+// Before positions the next registered callback right before the named one.
+func (p *Processor) Before(name string) *Callback {
+	return &Callback{processor: p, before: name}
+}
+
+// After positions the next registered callback right after the named one.
+func (p *Processor) After(name string) *Callback {
+	return &Callback{processor: p, after: name}
+}
+
+// Register appends a callback to the end of the chain.
+func (p *Processor) Register(name string, fn func(*Statement)) error {
+	return (&Callback{processor: p}).Register(name, fn)
+}
+
+// Register adds the callback at the position chosen by Before or After.
+func (c *Callback) Register(name string, fn func(*Statement)) error {
+	p := c.processor
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.index(name) >= 0 {
+		return fmt.Errorf("callback %s already registered on %s", name, p.name)
+	}
+
+	pos := len(p.callbacks)
+	switch {
+	case c.before != "":
+		if pos = p.index(c.before); pos < 0 {
+			return fmt.Errorf("callback %s not found on %s", c.before, p.name)
+		}
+	case c.after != "":
+		if pos = p.index(c.after); pos < 0 {
+			return fmt.Errorf("callback %s not found on %s", c.after, p.name)
+		}
+		pos++
+	}
+
+	p.callbacks = append(p.callbacks, nil)
+	copy(p.callbacks[pos+1:], p.callbacks[pos:])
+	p.callbacks[pos] = &callback{name: name, handler: fn}
+	return nil
+}
+
+// Replace swaps the handler of a registered callback, keeping its position.
+func (p *Processor) Replace(name string, fn func(*Statement)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.index(name)
+	if i < 0 {
+		return fmt.Errorf("callback %s not found on %s", name, p.name)
+	}
+	p.callbacks[i] = &callback{name: name, handler: fn}
+	return nil
+}
+
+// Remove deletes a registered callback from the chain.
+func (p *Processor) Remove(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.index(name)
+	if i < 0 {
+		return fmt.Errorf("callback %s not found on %s", name, p.name)
+	}
+	p.callbacks = append(p.callbacks[:i], p.callbacks[i+1:]...)
+	return nil
+}
+
+// Names returns the names of the registered callbacks in execution order.
+func (p *Processor) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make([]string, 0, len(p.callbacks))
+	for _, c := range p.callbacks {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Execute runs every callback of the chain in order. Callbacks keep running after
+// an error so that later ones can observe it through stmt.Error.
+func (p *Processor) Execute(stmt *Statement) {
+	p.mu.RLock()
+	callbacks := make([]*callback, len(p.callbacks))
+	copy(callbacks, p.callbacks)
+	p.mu.RUnlock()
+
+	for _, c := range callbacks {
+		c.handler(stmt)
+	}
+}
+
+func (p *Processor) index(name string) int {
+	for i, c := range p.callbacks {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// queryCallback runs the statement as a query unless an earlier callback failed.
+func queryCallback(stmt *Statement) {
+	if stmt.Error != nil {
+		return
+	}
+	logger.Info(stmt.SQL, stmt.Vars)
+	db := stmt.Session.readDB(stmt.SQL)
+	if stmt.singleRow {
+		stmt.Row = db.QueryRowContext(stmt.Context, stmt.SQL, stmt.Vars...)
+		if stmt.Session.failover(stmt.Context, db, stmt.Row.Err()) {
+			stmt.Row = stmt.Session.db.QueryRowContext(stmt.Context, stmt.SQL, stmt.Vars...)
+		}
+		return
+	}
+	rows, err := db.QueryContext(stmt.Context, stmt.SQL, stmt.Vars...)
+	if stmt.Session.failover(stmt.Context, db, err) {
+		rows, err = stmt.Session.db.QueryContext(stmt.Context, stmt.SQL, stmt.Vars...)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+	}
+	stmt.Rows = rows
+	stmt.AddError(err)
+}
+
+// rawCallback executes the statement unless an earlier callback failed.
+func rawCallback(stmt *Statement) {
+	if stmt.Error != nil {
+		return
+	}
+	logger.Info(stmt.SQL, stmt.Vars)
+	result, err := stmt.Session.db.ExecContext(stmt.Context, stmt.SQL, stmt.Vars...)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+	stmt.Result = result
+	stmt.AddError(err)
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-labx/orm/dialect"
+)
+
+func TestProcessorOrder(t *testing.T) {
+	var called []string
+	record := func(name string) func(*Statement) {
+		return func(*Statement) { called = append(called, name) }
+	}
+
+	p := NewCallbacks().Query()
+	if err := p.Replace("orm:query", record("orm:query")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Before("orm:query").Register("tenant", record("tenant")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.After("orm:query").Register("metrics", record("metrics")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Before("tenant").Register("trace", record("trace")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Register("audit", record("audit")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"trace", "tenant", "orm:query", "metrics", "audit"}
+	if !reflect.DeepEqual(p.Names(), expected) {
+		t.Fatalf("expected order %v, got %v", expected, p.Names())
+	}
+	p.Execute(&Statement{})
+	if !reflect.DeepEqual(called, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, called)
+	}
+
+	if err := p.Remove("trace"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Register("tenant", record("tenant")); err == nil {
+		t.Fatal("expected duplicate registration to fail")
+	}
+	if err := p.Before("missing").Register("x", record("x")); err == nil {
+		t.Fatal("expected registration before unknown callback to fail")
+	}
+}
+
+func TestProcessorError(t *testing.T) {
+	p := NewCallbacks().Raw()
+	errDenied := errors.New("denied")
+	_ = p.Before("orm:raw").Register("deny", func(stmt *Statement) {
+		stmt.AddError(errDenied)
+	})
+
+	var seen error
+	_ = p.After("orm:raw").Register("observe", func(stmt *Statement) {
+		seen = stmt.Error
+	})
+
+	// orm:raw must not touch the nil database once an error is recorded.
+	stmt := &Statement{Session: &Session{}}
+	p.Execute(stmt)
+	if stmt.Error != errDenied || seen != errDenied {
+		t.Fatalf("expected %v, got %v (observed %v)", errDenied, stmt.Error, seen)
+	}
+}
+
+func TestQueryRowCallbackError(t *testing.T) {
+	callbacks := NewCallbacks()
+	errDenied := errors.New("denied")
+	_ = callbacks.Query().Before("orm:query").Register("deny", func(stmt *Statement) {
+		stmt.AddError(errDenied)
+	})
+
+	mysql, _ := dialect.GetDialect("mysql")
+	s := New(nil, mysql)
+	s.SetCallbacks(callbacks)
+	row := s.Raw("SELECT 1").QueryRow()
+	if err := row.Err(); err != errDenied {
+		t.Fatalf("expected Err to report %v, got %v", errDenied, err)
+	}
+	var n int
+	if err := row.Scan(&n); err != errDenied {
+		t.Fatalf("expected Scan to report %v, got %v", errDenied, err)
+	}
+}
+
+func TestQueryCallbackNoResult(t *testing.T) {
+	callbacks := NewCallbacks()
+	if err := callbacks.Query().Replace("orm:query", func(*Statement) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := callbacks.Raw().Replace("orm:raw", func(*Statement) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	mysql, _ := dialect.GetDialect("mysql")
+	s := New(nil, mysql)
+	s.SetCallbacks(callbacks)
+	if rows, err := s.Raw("SELECT 1").Query(); rows != nil || err != errNoResult {
+		t.Fatalf("expected Query to fail with %v, got %v", errNoResult, err)
+	}
+	var n int
+	if err := s.Raw("SELECT 1").QueryRow().Scan(&n); err != errNoResult {
+		t.Fatalf("expected Scan to fail with %v, got %v", errNoResult, err)
+	}
+	if _, err := s.Raw("DELETE FROM user").Exec(); err != errNoResult {
+		t.Fatalf("expected Exec to fail with %v, got %v", errNoResult, err)
+	}
+	if err := ErrRow(nil).Err(); err != errNoResult {
+		t.Fatalf("expected ErrRow(nil) to report %v, got %v", errNoResult, err)
+	}
+}
@@ -0,0 +1,19 @@
+package session
+
+// Clause is an option that changes how the next statement of a Session is executed.
+type Clause func(*Session)
+
+// UsePrimary forces the next query of the Session to the primary connection instead of a replica.
+func UsePrimary() Clause {
+	return func(s *Session) {
+		s.usePrimary = true
+	}
+}
+
+// Clauses applies the given clauses to the next statement of the Session.
+func (s *Session) Clauses(clauses ...Clause) *Session {
+	for _, c := range clauses {
+		c(s)
+	}
+	return s
+}
@@ -3,6 +3,9 @@ package session
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
 	"strings"
 
 	"github.com/go-labx/orm/dialect"
@@ -12,25 +15,41 @@ import (
 
 // Session struct holds the database connection and the SQL query to be executed.
 type Session struct {
-	db       *sql.DB // Database connection
-	dialect  dialect.Dialect
-	refTable *schema.Schema
-	sql      strings.Builder // SQL query
-	sqlArgs  []interface{}   // Arguments for the SQL query
+	db        *sql.DB // Database connection
+	dialect   dialect.Dialect
+	callbacks *Callbacks
+	refTable  *schema.Schema
+	sql       strings.Builder // SQL query
+	sqlArgs   []interface{}   // Arguments for the SQL query
+	whereAt   int             // Offset just past the WHERE written by Where, 0 when none
+	err       error           // Error found while building the SQL query
+
+	replicas   Replicas // Replicas that serve queries
+	usePrimary bool     // Whether queries are forced to the primary
 }
 
 // New creates a new Session with the provided database connection.
+// The Session runs the built-in executors only until SetCallbacks is called.
 func New(db *sql.DB, dialect dialect.Dialect) *Session {
 	return &Session{
-		db:      db,
-		dialect: dialect,
+		db:        db,
+		dialect:   dialect,
+		callbacks: defaultCallbacks,
 	}
 }
 
+// SetCallbacks sets the callback processors run by the statements of the Session.
+func (s *Session) SetCallbacks(callbacks *Callbacks) {
+	s.callbacks = callbacks
+}
+
 // Clear resets the SQL query and its arguments in the Session.
 func (s *Session) Clear() {
 	s.sql.Reset()
 	s.sqlArgs = nil
+	s.whereAt = 0
+	s.err = nil
+	s.usePrimary = false
 }
 
 // DB returns the database connection from the Session.
@@ -38,8 +57,113 @@ func (s *Session) DB() *sql.DB {
 	return s.db
 }
 
+// Replicas balances the queries of a Session across read replicas.
+type Replicas interface {
+	// Pick returns the replica that serves the next query, or nil to use the primary.
+	Pick() *sql.DB
+	// MarkDown reports that a query on the replica failed with a connection error.
+	MarkDown(db *sql.DB)
+}
+
+// SetReplicas sets the read replicas serving the read-only queries of the Session, which
+// are plain SELECT statements; anything else runs on the primary. A query that fails on
+// a replica with a connection error marks it down and is retried on the primary.
+func (s *Session) SetReplicas(replicas Replicas) {
+	s.replicas = replicas
+}
+
+// readDB returns the connection that serves the query.
+func (s *Session) readDB(sql string) *sql.DB {
+	if s.usePrimary || s.replicas == nil || !isReadOnly(sql) {
+		return s.db
+	}
+	if db := s.replicas.Pick(); db != nil {
+		return db
+	}
+	return s.db
+}
+
+// writeWords mark a statement that writes, calls a routine or takes a lock, and so must
+// run on the primary.
+var writeWords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true, "MERGE": true, "INTO": true,
+	"CALL": true, "LOCK": true, "GET_LOCK": true, "RELEASE_LOCK": true, "RELEASE_ALL_LOCKS": true,
+	"IS_FREE_LOCK": true, "IS_USED_LOCK": true,
+}
+
+// isReadOnly reports whether the SQL is a SELECT, possibly with a WITH clause, that
+// neither writes nor locks rows, as FOR UPDATE and FOR SHARE do.
+func isReadOnly(sql string) bool {
+	tokens := sqlTokens(sql)
+	first := 0
+	for first < len(tokens) && tokens[first] == "(" {
+		first++
+	}
+	if first == len(tokens) || tokens[first] != "SELECT" && tokens[first] != "WITH" {
+		return false
+	}
+	for i, token := range tokens {
+		if writeWords[token] || strings.HasPrefix(token, "PG_ADVISORY") ||
+			token == "SHARE" && i > 0 && (tokens[i-1] == "FOR" || tokens[i-1] == "KEY") {
+			return false
+		}
+	}
+	return true
+}
+
+// failover reports whether a query that failed with err on db should be retried on
+// the primary, marking the replica down when it should.
+func (s *Session) failover(ctx context.Context, db *sql.DB, err error) bool {
+	if db == s.db || s.replicas == nil || !isConnError(ctx, err) {
+		return false
+	}
+	logger.Error(err.Error())
+	s.replicas.MarkDown(db)
+	return true
+}
+
+// isConnError reports whether err means the database could not be reached, as opposed
+// to an error in the statement itself or the caller's context being done, which
+// net.Error also matches.
+func isConnError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)
+}
+
+// addError logs err and keeps the first error found while building the SQL query,
+// which fails the statement when it executes.
+func (s *Session) addError(err error) {
+	logger.Error(err)
+	if s.err == nil {
+		s.err = err
+	}
+}
+
 // Raw sets the SQL query and its arguments in the Session.
+// A single Named or struct argument supplies the values of :name parameters in the query,
+// struct fields being matched to names as Rows.Scan matches them to columns.
+// Slice arguments are expanded into one placeholder per element, as needed by IN (?).
 func (s *Session) Raw(sql string, args ...interface{}) *Session {
+	if source, ok := namedArg(args); ok {
+		bound, vars, err := bindNamed(sql, source)
+		_, isNamed := source.(Named)
+		switch {
+		case err != nil:
+			s.addError(err)
+		case isNamed || len(vars) > 0:
+			sql, args = bound, vars
+		}
+	}
+	expanded, vars, err := expandSlices(sql, args)
+	if err != nil {
+		s.addError(err)
+	} else {
+		sql, args = expanded, vars
+	}
 	s.sql.WriteString(sql)
 	s.sql.WriteString(" ")
 	s.sqlArgs = append(s.sqlArgs, args...)
@@ -48,54 +172,63 @@ func (s *Session) Raw(sql string, args ...interface{}) *Session {
 
 // Exec executes the SQL query in the Session and returns the result.
 func (s *Session) Exec() (result sql.Result, err error) {
-	defer s.Clear()
-	logger.Info(s.sql.String(), s.sqlArgs)
-	if result, err = s.db.Exec(s.sql.String(), s.sqlArgs...); err != nil {
-		logger.Error(err.Error())
-	}
-	return result, nil
+	return s.ExecContext(context.Background())
 }
 
 // ExecContext executes the SQL query in the Session with a context and returns the result.
 func (s *Session) ExecContext(ctx context.Context) (result sql.Result, err error) {
 	defer s.Clear()
-	logger.Info(s.sql.String(), s.sqlArgs)
-	if result, err = s.db.ExecContext(ctx, s.sql.String(), s.sqlArgs...); err != nil {
-		logger.Error(err.Error())
+	stmt := s.statement(ctx)
+	s.callbacks.Raw().Execute(stmt)
+	if stmt.Result == nil {
+		stmt.AddError(errNoResult)
 	}
-	return result, nil
+	return stmt.Result, stmt.Error
 }
 
 // Query executes the SQL query in the Session and returns the rows.
 func (s *Session) Query() (result *sql.Rows, err error) {
-	defer s.Clear()
-	logger.Info(s.sql.String(), s.sqlArgs)
-	if result, err = s.db.Query(s.sql.String(), s.sqlArgs...); err != nil {
-		logger.Error(err.Error())
-	}
-	return result, nil
+	return s.QueryContext(context.Background())
 }
 
 // QueryContext executes the SQL query in the Session with a context and returns the rows.
 func (s *Session) QueryContext(ctx context.Context) (result *sql.Rows, err error) {
 	defer s.Clear()
-	logger.Info(s.sql.String(), s.sqlArgs)
-	if result, err = s.db.QueryContext(ctx, s.sql.String(), s.sqlArgs...); err != nil {
-		logger.Error(err.Error())
+	stmt := s.statement(ctx)
+	s.callbacks.Query().Execute(stmt)
+	if stmt.Rows == nil {
+		stmt.AddError(errNoResult)
 	}
-	return result, nil
+	return stmt.Rows, stmt.Error
 }
 
 // QueryRow executes the SQL query in the Session and returns the first row.
 func (s *Session) QueryRow() *sql.Row {
-	defer s.Clear()
-	logger.Info(s.sql.String(), s.sqlArgs)
-	return s.db.QueryRow(s.sql.String(), s.sqlArgs...)
+	return s.QueryRowContext(context.Background())
 }
 
 // QueryRowContext executes the SQL query in the Session with a context and returns the first row.
+// When a callback aborts the query, the row's Err and Scan report the callback's error.
 func (s *Session) QueryRowContext(ctx context.Context) *sql.Row {
 	defer s.Clear()
-	logger.Info(s.sql.String(), s.sqlArgs)
-	return s.db.QueryRowContext(ctx, s.sql.String(), s.sqlArgs...)
+	stmt := s.statement(ctx)
+	stmt.singleRow = true
+	s.callbacks.Query().Execute(stmt)
+	if stmt.Row == nil {
+		// A callback aborted the query before it reached the database.
+		stmt.AddError(errNoResult)
+		return ErrRow(stmt.Error)
+	}
+	return stmt.Row
+}
+
+// statement captures the SQL query and its arguments for the callback chain.
+func (s *Session) statement(ctx context.Context) *Statement {
+	return &Statement{
+		Context: ctx,
+		Session: s,
+		SQL:     rebind(s.dialect, s.sql.String()),
+		Vars:    s.sqlArgs,
+		Error:   s.err,
+	}
 }
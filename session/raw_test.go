@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		sql      string
+		readOnly bool
+	}{
+		{"SELECT * FROM t WHERE id = ?", true},
+		{"select * from t", true},
+		{"WITH recent AS (SELECT * FROM t) SELECT * FROM recent", true},
+		{"(SELECT a FROM t) UNION (SELECT a FROM u)", true},
+		{"SELECT 'for update' FROM t -- LOCK\n", true},
+		{"SELECT * FROM t FOR UPDATE", false},
+		{"SELECT * FROM t FOR SHARE", false},
+		{"SELECT * FROM t FOR KEY SHARE", false},
+		{"SELECT * FROM t LOCK IN SHARE MODE", false},
+		{"SELECT GET_LOCK('job', 10)", false},
+		{"SELECT pg_advisory_lock(1)", false},
+		{"SELECT * INTO OUTFILE '/tmp/t' FROM t", false},
+		{"WITH gone AS (DELETE FROM t RETURNING id) SELECT * FROM gone", false},
+		{"INSERT INTO t (a) VALUES (?) RETURNING id", false},
+		{"CALL refresh()", false},
+		{"SHOW TABLES", false},
+	}
+
+	for _, tt := range tests {
+		if got := isReadOnly(tt.sql); got != tt.readOnly {
+			t.Errorf("isReadOnly(%q) = %v, expected %v", tt.sql, got, tt.readOnly)
+		}
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		expected bool
+	}{
+		{"Network error", context.Background(), refused, true},
+		{"Bad connection", context.Background(), fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{"Statement error", context.Background(), errors.New("syntax error"), false},
+		{"Deadline", context.Background(), fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"Canceled", context.Background(), context.Canceled, false},
+		{"Network error after cancel", canceled, refused, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnError(tt.ctx, tt.err); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// ErrRow returns a *sql.Row whose Err and Scan report err, for callers that must
+// return a row although the query never reached the database. A nil err is reported
+// as a missing result, since a *sql.Row without rows or an error cannot be scanned.
+func ErrRow(err error) *sql.Row {
+	if err == nil {
+		err = errNoResult
+	}
+	db := sql.OpenDB(errConnector{err: err})
+	defer db.Close()
+	return db.QueryRow("")
+}
+
+// errConnector fails every connection attempt with err, so database/sql stores it in the row.
+type errConnector struct {
+	err error
+}
+
+func (c errConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+func (c errConnector) Driver() driver.Driver {
+	return c
+}
+
+func (c errConnector) Open(string) (driver.Conn, error) {
+	return nil, c.err
+}
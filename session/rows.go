@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-labx/orm/schema"
+)
+
+// Rows iterates over the result of a query, scanning one row at a time into a model.
+type Rows struct {
+	rows    *sql.Rows
+	columns []string
+	typ     reflect.Type  // model type the field indexes were resolved for
+	fields  [][]int       // field index of each column, nil when no field matches
+	dest    []interface{} // scan buffer reused for every row
+	discard interface{}   // target of columns without a matching field
+}
+
+// Rows executes the SQL query in the Session and returns an iterator over its rows.
+func (s *Session) Rows() (*Rows, error) {
+	return s.RowsContext(context.Background())
+}
+
+// RowsContext executes the SQL query in the Session with a context and returns an iterator over its rows.
+func (s *Session) RowsContext(ctx context.Context) (*Rows, error) {
+	rows, err := s.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	return &Rows{
+		rows:    rows,
+		columns: columns,
+		dest:    make([]interface{}, len(columns)),
+	}, nil
+}
+
+// Next prepares the next row for Scan. It returns false when the rows are exhausted or an error occurred.
+func (r *Rows) Next() bool {
+	return r.rows.Next()
+}
+
+// Scan copies the columns of the current row into the fields of the model pointed to by dest.
+// A column matches the field whose db tag equals its name or, for untagged fields, the field
+// whose name equals it case-insensitively; columns without a matching field are skipped.
+// Fields of types database/sql cannot scan into make Scan return an error.
+func (r *Rows) Scan(dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("scan destination must be a non-nil pointer to struct, got %T", dest)
+	}
+	value = value.Elem()
+
+	if r.typ != value.Type() {
+		r.typ = value.Type()
+		r.fields = columnFields(r.typ, r.columns)
+	}
+
+	for i, index := range r.fields {
+		if index != nil {
+			r.dest[i] = value.FieldByIndex(index).Addr().Interface()
+		} else {
+			r.dest[i] = &r.discard
+		}
+	}
+	return r.rows.Scan(r.dest...)
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (r *Rows) Err() error {
+	return r.rows.Err()
+}
+
+// Close closes the underlying rows, releasing the connection.
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
+
+// columnFields resolves the field index of each column in the struct type.
+func columnFields(typ reflect.Type, columns []string) [][]int {
+	tagged := make(map[string][]int)
+	untagged := make(map[string][]int)
+	for _, field := range schema.ColumnFields(typ) {
+		if _, ok := field.Tag.Lookup("db"); ok {
+			tagged[schema.ColumnName(field)] = field.Index
+		} else {
+			untagged[strings.ToLower(field.Name)] = field.Index
+		}
+	}
+
+	fields := make([][]int, len(columns))
+	for i, column := range columns {
+		if index, ok := tagged[column]; ok {
+			fields[i] = index
+		} else {
+			fields[i] = untagged[strings.ToLower(column)]
+		}
+	}
+	return fields
+}
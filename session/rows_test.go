@@ -0,0 +1,128 @@
+package session
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/go-labx/orm/dialect"
+	"github.com/go-labx/orm/internal/stubdriver"
+)
+
+type Account struct {
+	ID        int64          `db:"id"`
+	CreatedAt int64          `db:"created_at"`
+	Nickname  sql.NullString `db:"nickname"`
+	Email     *string
+}
+
+func newStubSession(columns []string, values ...[]driver.Value) *Session {
+	mysql, _ := dialect.GetDialect("mysql")
+	connector := &stubdriver.Connector{Columns: columns, Rows: values}
+	return New(connector.DB(), mysql)
+}
+
+func TestRowsScan(t *testing.T) {
+	s := newStubSession(
+		[]string{"id", "created_at", "nickname", "EMAIL", "unknown"},
+		[]driver.Value{int64(1), int64(100), "tom", "tom@example.com", "x"},
+		[]driver.Value{int64(2), int64(200), nil, nil, "y"},
+	)
+
+	rows, err := s.Raw("SELECT * FROM account").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	var buffer *interface{}
+	for rows.Next() {
+		var a Account
+		if err = rows.Scan(&a); err != nil {
+			t.Fatal(err)
+		}
+		if buffer == nil {
+			buffer = &rows.dest[0]
+		} else if buffer != &rows.dest[0] {
+			t.Error("expected the scan buffer to be reused across rows")
+		}
+		accounts = append(accounts, a)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %d", len(accounts))
+	}
+	first, second := accounts[0], accounts[1]
+	if first.ID != 1 || first.CreatedAt != 100 || first.Nickname.String != "tom" ||
+		first.Email == nil || *first.Email != "tom@example.com" {
+		t.Errorf("unexpected first account %+v", first)
+	}
+	if second.ID != 2 || second.CreatedAt != 200 || second.Nickname.Valid || second.Email != nil {
+		t.Errorf("unexpected second account %+v", second)
+	}
+}
+
+func TestRowsScanErrors(t *testing.T) {
+	type unscannable struct {
+		Meta struct{ Name string } `db:"meta"`
+	}
+
+	s := newStubSession([]string{"meta"}, []driver.Value{"x"})
+	rows, err := s.Raw("SELECT meta FROM account").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+	if err = rows.Scan(&unscannable{}); err == nil {
+		t.Error("expected scanning into a struct field to fail")
+	}
+	if err = rows.Scan(Account{}); err == nil {
+		t.Error("expected scanning into a non-pointer to fail")
+	}
+}
+
+func TestRowsScanCreatedTable(t *testing.T) {
+	type Profile struct {
+		ID       int64 `db:"id"`
+		Nickname string
+	}
+
+	mysql, _ := dialect.GetDialect("mysql")
+	connector := &stubdriver.Connector{}
+	s := New(connector.DB(), mysql)
+	if err := s.Model(&Profile{}).CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	// answer queries with the columns of the table CreateTable created
+	ddl := connector.Queries()[0]
+	for _, column := range strings.Split(ddl[strings.Index(ddl, "(")+1:strings.LastIndex(ddl, ")")], ",") {
+		connector.Columns = append(connector.Columns, strings.Fields(column)[0])
+	}
+	connector.Rows = [][]driver.Value{{int64(7), "tom"}}
+
+	rows, err := s.Raw("SELECT * FROM Profile").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var p Profile
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+	if err = rows.Scan(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 7 || p.Nickname != "tom" {
+		t.Errorf("expected the columns of %q to scan into the model, got %+v", ddl, p)
+	}
+}
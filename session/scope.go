@@ -0,0 +1,10 @@
+package session
+
+// Scopes applies the given functions to the Session in order, so that common conditions
+// can be shared, e.g. s.Raw("SELECT * FROM user").Scopes(Active, OfTenant(id)).Rows().
+func (s *Session) Scopes(funcs ...func(*Session) *Session) *Session {
+	for _, fn := range funcs {
+		s = fn(s)
+	}
+	return s
+}
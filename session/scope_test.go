@@ -0,0 +1,35 @@
+package session
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-labx/orm/clause"
+	"github.com/go-labx/orm/dialect"
+)
+
+func TestScopes(t *testing.T) {
+	mysql, _ := dialect.GetDialect("mysql")
+	active := func(s *Session) *Session {
+		return s.Where(clause.Eq{Column: "active", Value: true})
+	}
+	ofTenant := func(id int) func(*Session) *Session {
+		return func(s *Session) *Session {
+			return s.Where(map[string]interface{}{"tenant_id": id})
+		}
+	}
+
+	s := New(nil, mysql)
+	s.Raw("SELECT * FROM user").Scopes(active, ofTenant(7))
+
+	stmt := s.statement(context.Background())
+	expected := "SELECT * FROM user WHERE (`active` = ?) AND (`tenant_id` = ?) "
+	if stmt.SQL != expected {
+		t.Errorf("expected %q, got %q", expected, stmt.SQL)
+	}
+	vars := []interface{}{true, 7}
+	if !reflect.DeepEqual(stmt.Vars, vars) {
+		t.Errorf("expected vars %v, got %v", vars, stmt.Vars)
+	}
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-labx/orm/clause"
+)
+
+// Where appends a condition to the SQL query in the Session. The query may be a
+// clause.Expression, a map with string keys of column values, or a model struct whose
+// non-zero fields are matched. The first call writes the WHERE clause and further calls
+// are joined with AND, each condition in parentheses. An empty condition adds nothing,
+// except in an UPDATE or DELETE without a WHERE clause, where it fails the statement
+// rather than letting it touch every row.
+//
+// Where must follow the FROM part of the query: it fails the statement when the SQL
+// written with Raw already has its own WHERE or has moved on to GROUP BY, HAVING,
+// ORDER BY, LIMIT and the like, or when the condition type is not supported.
+func (s *Session) Where(query interface{}) *Session {
+	expr, err := whereExpression(query)
+	if err != nil {
+		s.addError(err)
+		return s
+	}
+
+	verb, whereAt, after := whereState(s.sql.String())
+	if clause.IsEmpty(expr) {
+		if whereAt == 0 && (verb == "UPDATE" || verb == "DELETE") {
+			s.addError(fmt.Errorf("missing WHERE: empty where condition for %s", verb))
+		}
+		return s
+	}
+	switch {
+	case after != "":
+		s.addError(fmt.Errorf("where condition cannot follow %s", after))
+		return s
+	case whereAt > 0 && whereAt != s.whereAt:
+		s.addError(fmt.Errorf("where condition cannot extend a WHERE clause written with Raw"))
+		return s
+	}
+
+	sql, vars := clause.Build(s.dialect, expr)
+	if whereAt > 0 {
+		s.sql.WriteString("AND (")
+	} else {
+		s.sql.WriteString("WHERE")
+		s.whereAt = s.sql.Len()
+		s.sql.WriteString(" (")
+	}
+	s.sql.WriteString(sql)
+	s.sql.WriteString(") ")
+	s.sqlArgs = append(s.sqlArgs, vars...)
+	return s
+}
+
+// whereExpression converts a Where argument into an expression.
+func whereExpression(query interface{}) (clause.Expression, error) {
+	switch q := query.(type) {
+	case clause.Expression:
+		return q, nil
+	case map[string]interface{}:
+		return clause.Map(q), nil
+	}
+
+	value := reflect.ValueOf(query)
+	switch {
+	case value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String:
+		m := make(map[string]interface{}, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return clause.Map(m), nil
+	case value.Kind() == reflect.Struct,
+		value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Kind() == reflect.Struct:
+		return clause.Struct(query), nil
+	}
+	return nil, fmt.Errorf("unsupported where condition of type %T", query)
+}
+
+// afterWhere holds the keywords of the clauses that come after WHERE.
+var afterWhere = map[string]bool{
+	"GROUP": true, "HAVING": true, "WINDOW": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"FOR": true, "UNION": true, "INTERSECT": true, "EXCEPT": true,
+}
+
+// whereState scans the top-level keywords of the SQL of the current statement. It reports
+// the SELECT, UPDATE or DELETE keyword that starts the current statement, the offset just
+// past its WHERE keyword, 0 when there is none, and the keyword of a clause that must come
+// after WHERE, if any.
+func whereState(sql string) (verb string, whereAt int, after string) {
+	scanKeywords(sql, func(word, prev string, end int) {
+		switch {
+		case word == "SELECT", word == "DELETE", word == "UPDATE" && prev != "FOR":
+			verb, whereAt, after = word, 0, ""
+		case word == "WHERE":
+			whereAt = end
+		case afterWhere[word]:
+			after = word
+		}
+	})
+	return verb, whereAt, after
+}
+
+// scanKeywords calls visit for every word of the SQL outside parentheses and literals,
+// upper-cased, along with the word before it and the offset just past it.
+func scanKeywords(sql string, visit func(word, prev string, end int)) {
+	depth, prev := 0, ""
+	for i := 0; i < len(sql); {
+		if end := skipLiteral(sql, i); end > i {
+			i = end
+			continue
+		}
+		c := sql[i]
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case isNameByte(c, false):
+			end := i
+			for end < len(sql) && isNameByte(sql[end], false) {
+				end++
+			}
+			if depth == 0 {
+				word := strings.ToUpper(sql[i:end])
+				visit(word, prev, end)
+				prev = word
+			}
+			i = end
+			continue
+		}
+		i++
+	}
+}
@@ -0,0 +1,140 @@
+package session
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-labx/orm/clause"
+	"github.com/go-labx/orm/dialect"
+)
+
+func TestWhere(t *testing.T) {
+	type user struct {
+		Name      string
+		CreatedAt int64 `db:"created_at"`
+	}
+
+	tests := []struct {
+		name     string
+		build    func(s *Session) *Session
+		expected string
+		vars     []interface{}
+	}{
+		{
+			"Or stays grouped",
+			func(s *Session) *Session {
+				return s.Raw("SELECT * FROM t").
+					Where(clause.Or(clause.Eq{Column: "a", Value: 1}, clause.Eq{Column: "b", Value: 2})).
+					Where(clause.Eq{Column: "tenant_id", Value: 7})
+			},
+			"SELECT * FROM t WHERE (`a` = ? OR `b` = ?) AND (`tenant_id` = ?) ",
+			[]interface{}{1, 2, 7},
+		},
+		{
+			"Slice value is not expanded again",
+			func(s *Session) *Session {
+				return s.Raw("SELECT * FROM t").Where(clause.Eq{Column: "b", Value: []int{1, 2}})
+			},
+			"SELECT * FROM t WHERE (`b` = ?) ",
+			[]interface{}{[]int{1, 2}},
+		},
+		{
+			"Typed map and tagged struct",
+			func(s *Session) *Session {
+				return s.Raw("SELECT * FROM t").
+					Where(map[string]string{"kind": "a"}).
+					Where(&user{CreatedAt: 5}).
+					Raw("ORDER BY id")
+			},
+			"SELECT * FROM t WHERE (`kind` = ?) AND (`created_at` = ?) ORDER BY id ",
+			[]interface{}{"a", int64(5)},
+		},
+		{
+			"Empty condition on SELECT",
+			func(s *Session) *Session {
+				return s.Raw("SELECT * FROM t").Where(map[string]interface{}{})
+			},
+			"SELECT * FROM t ",
+			nil,
+		},
+		{
+			"Empty condition after a DELETE condition",
+			func(s *Session) *Session {
+				return s.Raw("DELETE FROM t").Where(clause.Eq{Column: "a", Value: 1}).Where(clause.And())
+			},
+			"DELETE FROM t WHERE (`a` = ?) ",
+			[]interface{}{1},
+		},
+		{
+			"Subquery and second statement",
+			func(s *Session) *Session {
+				return s.Raw("SELECT * FROM (SELECT * FROM t WHERE x = 1 ORDER BY id) AS sub").
+					Where(clause.Eq{Column: "a", Value: 1}).
+					Raw("UNION SELECT * FROM u").
+					Where(clause.Eq{Column: "b", Value: 2})
+			},
+			"SELECT * FROM (SELECT * FROM t WHERE x = 1 ORDER BY id) AS sub WHERE (`a` = ?) UNION SELECT * FROM u WHERE (`b` = ?) ",
+			[]interface{}{1, 2},
+		},
+	}
+
+	mysql, _ := dialect.GetDialect("mysql")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := tt.build(New(nil, mysql)).statement(context.Background())
+			if stmt.Error != nil {
+				t.Fatal(stmt.Error)
+			}
+			if stmt.SQL != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, stmt.SQL)
+			}
+			if !reflect.DeepEqual(stmt.Vars, tt.vars) {
+				t.Errorf("expected vars %v, got %v", tt.vars, stmt.Vars)
+			}
+		})
+	}
+}
+
+func TestWhereErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(s *Session) *Session
+	}{
+		{"String condition", func(s *Session) *Session { return s.Raw("SELECT * FROM t").Where("id = 1") }},
+		{"Nil pointer", func(s *Session) *Session { return s.Raw("SELECT * FROM t").Where((*struct{ A int })(nil)) }},
+		{"Raw WHERE", func(s *Session) *Session {
+			return s.Raw("SELECT * FROM t WHERE x = 1").Where(clause.Eq{Column: "a", Value: 1})
+		}},
+		{"After ORDER BY", func(s *Session) *Session {
+			return s.Raw("SELECT * FROM t ORDER BY id").Where(clause.Eq{Column: "a", Value: 1})
+		}},
+		{"After LIMIT", func(s *Session) *Session {
+			return s.Raw("SELECT * FROM t LIMIT 1").Where(clause.Eq{Column: "a", Value: 1})
+		}},
+		{"After FOR UPDATE", func(s *Session) *Session {
+			return s.Raw("SELECT * FROM t FOR UPDATE").Where(clause.Eq{Column: "a", Value: 1})
+		}},
+		{"Empty map on DELETE", func(s *Session) *Session {
+			return s.Raw("DELETE FROM t").Where(map[string]interface{}{})
+		}},
+		{"Zero struct on DELETE", func(s *Session) *Session {
+			return s.Raw("DELETE FROM t").Where(&struct{ ID int }{})
+		}},
+		{"Empty group on UPDATE", func(s *Session) *Session {
+			return s.Raw("UPDATE t SET a = ?", 1).Where(clause.And())
+		}},
+		{"Empty map on UPDATE after SELECT", func(s *Session) *Session {
+			return s.Raw("SELECT 1; UPDATE t SET a = ?", 1).Where(map[string]interface{}{})
+		}},
+	}
+
+	mysql, _ := dialect.GetDialect("mysql")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if stmt := tt.build(New(nil, mysql)).statement(context.Background()); stmt.Error == nil {
+				t.Errorf("expected an error, got SQL %q", stmt.SQL)
+			}
+		})
+	}
+}
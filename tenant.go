@@ -0,0 +1,209 @@
+package orm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-labx/orm/dialect"
+	"github.com/go-labx/orm/session"
+)
+
+// TenantResolver maps a context to the DataSource of the tenant it belongs to.
+// For schema-per-tenant setups it returns the shared DataSource with DBName set to the
+// tenant's schema. Returning nil routes to the DB's own connection.
+type TenantResolver func(ctx context.Context) (*DataSource, error)
+
+var errTenantPoolsClosed = errors.New("tenant connections are closed")
+
+// tenantPoolGrace is how long an evicted tenant pool stays open, and how often it is
+// checked again while it still has connections in use.
+const tenantPoolGrace = time.Minute
+
+// tenantPools lazily opens one connection pool per tenant DataSource and retires the
+// least recently used one once more than max pools are open.
+type tenantPools struct {
+	resolver TenantResolver
+	max      int
+	grace    time.Duration
+	open     func(*DataSource) (*sql.DB, dialect.Dialect, error)
+
+	mu       sync.Mutex
+	closed   bool
+	lru      *list.List               // front is the most recently used pool
+	pools    map[string]*list.Element // keyed by DSN
+	pending  map[string]*tenantOpen   // pools being opened, keyed by DSN
+	retiring map[string]*tenantPool   // evicted pools waiting to be idle, keyed by DSN
+}
+
+type tenantPool struct {
+	dsn     string
+	db      *sql.DB
+	dialect dialect.Dialect
+	retire  *time.Timer // closes the pool once it is evicted and idle
+}
+
+// tenantOpen lets concurrent sessions of a tenant wait for a single open of its pool.
+type tenantOpen struct {
+	done chan struct{}
+	pool *tenantPool
+	err  error
+}
+
+func newTenantPools(resolver TenantResolver, maxPools int) *tenantPools {
+	return &tenantPools{
+		resolver: resolver,
+		max:      maxPools,
+		grace:    tenantPoolGrace,
+		open:     openDB,
+		lru:      list.New(),
+		pools:    make(map[string]*list.Element),
+		pending:  make(map[string]*tenantOpen),
+		retiring: make(map[string]*tenantPool),
+	}
+}
+
+// SetTenantResolver routes sessions created with NewSessionContext to the DataSource
+// returned by resolver. At most maxPools tenant pools are kept in use; a value of 0 or
+// less keeps every pool. The least recently used pool is evicted beyond that, and is
+// closed only once it has had no connection in use for a grace period of a minute, so
+// that sessions already created on it keep working. An evicted pool requested again
+// before it is closed is reused. Replacing a resolver closes the pools opened for the
+// previous one.
+func (d *DB) SetTenantResolver(resolver TenantResolver, maxPools int) {
+	if old := d.tenants.Swap(newTenantPools(resolver, maxPools)); old != nil {
+		old.close()
+	}
+}
+
+// NewSessionContext creates a new session on the connection of the tenant resolved
+// from ctx, or on the current database connection when no resolver is set.
+func (d *DB) NewSessionContext(ctx context.Context) (*session.Session, error) {
+	tenants := d.tenants.Load()
+	if tenants == nil {
+		return d.NewSession(), nil
+	}
+
+	ds, err := tenants.resolver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ds == nil {
+		return d.NewSession(), nil
+	}
+
+	pool, err := tenants.get(ds)
+	if err != nil {
+		return nil, err
+	}
+	s := session.New(pool.db, pool.dialect)
+	s.SetCallbacks(d.callbacks)
+	return s, nil
+}
+
+// get returns the pool of the DataSource, opening it on first use. The pool is opened
+// without holding the lock, so a slow tenant database only delays its own sessions.
+func (t *tenantPools) get(ds *DataSource) (*tenantPool, error) {
+	dsn := ds.DSN()
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, errTenantPoolsClosed
+	}
+	if elem, ok := t.pools[dsn]; ok {
+		t.lru.MoveToFront(elem)
+		t.mu.Unlock()
+		return elem.Value.(*tenantPool), nil
+	}
+	if pool, ok := t.retiring[dsn]; ok {
+		pool.retire.Stop()
+		delete(t.retiring, dsn)
+		t.add(pool)
+		t.mu.Unlock()
+		return pool, nil
+	}
+	if op, ok := t.pending[dsn]; ok {
+		t.mu.Unlock()
+		<-op.done
+		return op.pool, op.err
+	}
+	op := &tenantOpen{done: make(chan struct{})}
+	t.pending[dsn] = op
+	t.mu.Unlock()
+
+	db, dial, err := t.open(ds)
+
+	t.mu.Lock()
+	delete(t.pending, dsn)
+	switch {
+	case err != nil:
+		op.err = err
+	case t.closed:
+		_ = db.Close()
+		op.err = errTenantPoolsClosed
+	default:
+		op.pool = &tenantPool{dsn: dsn, db: db, dialect: dial}
+		t.add(op.pool)
+	}
+	close(op.done)
+	t.mu.Unlock()
+	return op.pool, op.err
+}
+
+// add makes the pool the most recently used one and retires the least recently used
+// pools beyond max. It must be called with the lock held.
+func (t *tenantPools) add(pool *tenantPool) {
+	t.pools[pool.dsn] = t.lru.PushFront(pool)
+	for t.max > 0 && t.lru.Len() > t.max {
+		oldest := t.lru.Remove(t.lru.Back()).(*tenantPool)
+		delete(t.pools, oldest.dsn)
+		t.retiring[oldest.dsn] = oldest
+		oldest.retire = time.AfterFunc(t.grace, func() { t.closeIdle(oldest) })
+	}
+}
+
+// closeIdle closes a retired pool that has no connection in use, and checks it again
+// after the grace period otherwise.
+func (t *tenantPools) closeIdle(pool *tenantPool) {
+	t.mu.Lock()
+	if t.retiring[pool.dsn] != pool {
+		// the pool was reused or closed meanwhile
+		t.mu.Unlock()
+		return
+	}
+	if pool.db.Stats().InUse > 0 {
+		pool.retire.Reset(t.grace)
+		t.mu.Unlock()
+		return
+	}
+	delete(t.retiring, pool.dsn)
+	t.mu.Unlock()
+
+	_ = pool.db.Close()
+}
+
+// close closes every tenant pool, including retired ones, and fails later lookups.
+func (t *tenantPools) close() {
+	t.mu.Lock()
+	t.closed = true
+	pools := make([]*tenantPool, 0, t.lru.Len()+len(t.retiring))
+	for elem := t.lru.Front(); elem != nil; elem = elem.Next() {
+		pools = append(pools, elem.Value.(*tenantPool))
+	}
+	for _, pool := range t.retiring {
+		pool.retire.Stop()
+		pools = append(pools, pool)
+	}
+	t.lru.Init()
+	t.pools = make(map[string]*list.Element)
+	t.retiring = make(map[string]*tenantPool)
+	t.mu.Unlock()
+
+	for _, pool := range pools {
+		_ = pool.db.Close()
+	}
+}
@@ -0,0 +1,228 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-labx/orm/dialect"
+	"github.com/go-labx/orm/internal/stubdriver"
+	"github.com/go-labx/orm/session"
+)
+
+type tenantKey struct{}
+
+// newTenantTestDB returns a DB on a stub primary whose tenants, named by the tenant
+// value of the context, are opened on their own stub connectors.
+func newTenantTestDB(t *testing.T, maxPools int) (*DB, *stubdriver.Connector, map[string]*stubdriver.Connector, *[]string) {
+	mysql, _ := dialect.GetDialect(MySQL)
+	primary := &stubdriver.Connector{}
+	d := &DB{db: primary.DB(), dialect: mysql, callbacks: session.NewCallbacks()}
+
+	d.SetTenantResolver(func(ctx context.Context) (*DataSource, error) {
+		name, _ := ctx.Value(tenantKey{}).(string)
+		if name == "" {
+			return nil, nil
+		}
+		return NewDataSource(SetDBName(name)), nil
+	}, maxPools)
+
+	var mu sync.Mutex
+	connectors := make(map[string]*stubdriver.Connector)
+	opened := []string{}
+	d.tenants.Load().open = func(ds *DataSource) (*sql.DB, dialect.Dialect, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		connector := &stubdriver.Connector{}
+		connectors[ds.DBName] = connector
+		opened = append(opened, ds.DBName)
+		return connector.DB(), mysql, nil
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return d, primary, connectors, &opened
+}
+
+func tenantContext(name string) context.Context {
+	return context.WithValue(context.Background(), tenantKey{}, name)
+}
+
+func TestNewSessionContextFallback(t *testing.T) {
+	mysql, _ := dialect.GetDialect(MySQL)
+	primary := &stubdriver.Connector{}
+	d := &DB{db: primary.DB(), dialect: mysql, callbacks: session.NewCallbacks()}
+
+	s, err := d.NewSessionContext(tenantContext("a"))
+	if err != nil || s.DB() != d.db {
+		t.Fatalf("expected the primary without a resolver, got %v", err)
+	}
+
+	d, _, _, opened := newTenantTestDB(t, 0)
+	s, err = d.NewSessionContext(context.Background())
+	if err != nil || s.DB() != d.db || len(*opened) != 0 {
+		t.Fatalf("expected the primary when the resolver returns nil, got %v", err)
+	}
+}
+
+func TestTenantRouting(t *testing.T) {
+	d, primary, connectors, _ := newTenantTestDB(t, 0)
+
+	if _, err := d.ExecContext(tenantContext("a"), "DELETE FROM t"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.QueryContext(tenantContext("b"), "SELECT * FROM t"); err != nil {
+		t.Fatal(err)
+	}
+	if got := connectors["a"].Queries(); !reflect.DeepEqual(got, []string{"DELETE FROM t "}) {
+		t.Errorf("expected the exec on tenant a, got %v", got)
+	}
+	if got := connectors["b"].Queries(); !reflect.DeepEqual(got, []string{"SELECT * FROM t "}) {
+		t.Errorf("expected the query on tenant b, got %v", got)
+	}
+	if got := primary.Queries(); len(got) != 0 {
+		t.Errorf("expected nothing on the primary, got %v", got)
+	}
+}
+
+func TestTenantEviction(t *testing.T) {
+	d, _, _, opened := newTenantTestDB(t, 2)
+	d.tenants.Load().grace = 20 * time.Millisecond
+
+	sessions := make(map[string]*session.Session)
+	for _, name := range []string{"a", "b", "a", "c"} {
+		s, err := d.NewSessionContext(tenantContext(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[name] = s
+	}
+	if !reflect.DeepEqual(*opened, []string{"a", "b", "c"}) {
+		t.Errorf("expected each tenant to be opened once, got %v", *opened)
+	}
+
+	// the session created before b was evicted keeps working while it uses the pool
+	rows, err := sessions["b"].Raw("SELECT * FROM t").Rows()
+	if err != nil {
+		t.Fatalf("expected the evicted pool to stay open, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err = sessions["b"].DB().Ping(); err != nil {
+		t.Fatalf("expected a pool in use to stay open, got %v", err)
+	}
+	_ = rows.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for sessions["b"].DB().Ping() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the evicted pool to be closed once idle")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err = sessions["a"].DB().Ping(); err != nil {
+		t.Errorf("expected the recently used pool to stay open, got %v", err)
+	}
+
+	if _, err = d.NewSessionContext(tenantContext("b")); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*opened, []string{"a", "b", "c", "b"}) {
+		t.Errorf("expected the closed tenant to be opened again, got %v", *opened)
+	}
+}
+
+func TestTenantEvictedReuse(t *testing.T) {
+	d, _, _, opened := newTenantTestDB(t, 1)
+	d.tenants.Load().grace = time.Hour
+
+	a, err := d.NewSessionContext(tenantContext("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := d.NewSessionContext(tenantContext("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := d.NewSessionContext(tenantContext("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.DB() != a.DB() || !reflect.DeepEqual(*opened, []string{"a", "b"}) {
+		t.Errorf("expected the evicted pool to be reused, opened %v", *opened)
+	}
+
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.DB().Ping(); err == nil {
+		t.Error("expected Close to close the evicted pools too")
+	}
+}
+
+func TestTenantSlowOpen(t *testing.T) {
+	d, _, _, _ := newTenantTestDB(t, 0)
+	if _, err := d.NewSessionContext(tenantContext("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := d.tenants.Load()
+	open := tenants.open
+	release := make(chan struct{})
+	var calls int
+	var mu sync.Mutex
+	tenants.open = func(ds *DataSource) (*sql.DB, dialect.Dialect, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+		return open(ds)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := d.NewSessionContext(tenantContext("slow")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = d.NewSessionContext(tenantContext("a"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a slow tenant blocked a cached one")
+	}
+
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("expected concurrent sessions to share one open, got %d", calls)
+	}
+}
+
+func TestTenantClose(t *testing.T) {
+	d, _, _, _ := newTenantTestDB(t, 0)
+	s, err := d.NewSessionContext(tenantContext("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenants := d.tenants.Load()
+
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.DB().Ping(); err == nil {
+		t.Error("expected Close to close the tenant pools")
+	}
+	if _, err = tenants.get(NewDataSource(SetDBName("a"))); err != errTenantPoolsClosed {
+		t.Errorf("expected lookups after close to fail, got %v", err)
+	}
+}
@@ -2,19 +2,26 @@ package orm
 
 import (
 	"bytes"
+	"sort"
 	"strings"
 	"unicode"
 )
 
 // MapToString is a utility function that converts a map into a string.
 // Each key-value pair in the map is converted into a "key=value" string, and these strings are joined with "&" as the separator.
-// The resulting string does not end with "&".
+// The pairs are ordered by key, so the result is deterministic.
 func MapToString(m map[string]string) string {
-	var str string
-	for key, value := range m {
-		str += key + "=" + value + "&"
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
-	return strings.TrimRight(str, "&")
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+m[key])
+	}
+	return strings.Join(pairs, "&")
 }
 
 // ToSnakeCase is a utility function that converts a given string into snake case.
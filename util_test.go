@@ -18,6 +18,16 @@ func TestMapToString(t *testing.T) {
 			input:    map[string]string{"key": "value"},
 			expected: "key=value",
 		},
+		{
+			name:     "Test Case 3",
+			input:    map[string]string{"c": "3", "a": "1", "b": "2"},
+			expected: "a=1&b=2&c=3",
+		},
+		{
+			name:     "Test Case 4",
+			input:    map[string]string{},
+			expected: "",
+		},
 	}
 
 	for _, tc := range testCases {